@@ -1,8 +1,8 @@
-package ACO
+package main
 
 import (
+	"context"
 	"math"
-	"math/rand"
 )
 
 type antColonySystem struct {
@@ -14,9 +14,69 @@ type antColonySystem struct {
 
 // Solve ATSP returns optimal cost and solution to the ATSP specified by matrix graph.
 // The solution is heavily dependent on specified values for alfa, beta, rho, q & m.
-func SolveACS(graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, tau0 float64, phi float64, q0 float64, iterations int) (float64, []int) {
-	parent := colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m}
-	c := antColonySystem{colony: parent, tau0: tau0, phi: phi, q0: q0}
+// localSearch selects an optional tour-refinement pass applied to each ant before pheromone deposition.
+// workers bounds how many goroutines construct ant tours concurrently (0 means runtime.NumCPU()).
+// candidateListSize restricts chooseNode to each node's K nearest neighbors (0 disables the restriction).
+func SolveACS(graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, tau0 float64, phi float64, q0 float64, iterations int, localSearch LocalSearch, workers int, candidateListSize int) (float64, []int) {
+	c := antColonySystem{
+		colony: colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers, candidateListSize: candidateListSize},
+		tau0:   tau0, phi: phi, q0: q0,
+	}
+
+	c.configurateSolver()
+
+	for gen := 0; gen < iterations; gen++ {
+		c.constructAntSolutions()
+		c.updateBestPath()
+		c.updatePheromones()
+
+	}
+
+	return c.bestCost, c.bestPath
+}
+
+// SolveACSFrom is SolveACS with an optional warm-start Snapshot and a
+// context that can cancel the run between generations, returning whatever
+// is currently the best-known solution along with a Snapshot for
+// checkpointing. It returns an error, without running any generations, if
+// snapshot was taken against a differently-sized problem.
+func SolveACSFrom(ctx context.Context, graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, tau0 float64, phi float64, q0 float64, iterations int, localSearch LocalSearch, workers int, candidateListSize int, snapshot *Snapshot) (float64, []int, Snapshot, error) {
+	c := antColonySystem{
+		colony: colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers, candidateListSize: candidateListSize},
+		tau0:   tau0, phi: phi, q0: q0,
+	}
+
+	c.configurateSolver()
+	if snapshot != nil {
+		if err := c.Restore(*snapshot); err != nil {
+			return 0, nil, Snapshot{}, err
+		}
+	}
+
+	for ; c.gen < iterations; c.gen++ {
+		select {
+		case <-ctx.Done():
+			return c.bestCost, c.bestPath, c.Snapshot(), nil
+		default:
+		}
+
+		c.constructAntSolutions()
+		c.updateBestPath()
+		c.updatePheromones()
+	}
+
+	return c.bestCost, c.bestPath, c.Snapshot(), nil
+}
+
+// SolveACSProblem is SolveACS generalized to any Problem, not just the ATSP
+// adjacency matrices ATSPProblem wraps. Candidate lists are not available
+// here since they rely on graph edge distances, and for the same reason
+// localSearch is silently skipped (see ant.refine).
+func SolveACSProblem(problem Problem, alfa float64, beta float64, rho float64, q float64, m int, tau0 float64, phi float64, q0 float64, iterations int, localSearch LocalSearch, workers int) (float64, []int) {
+	c := antColonySystem{
+		colony: colony{problem: problem, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers},
+		tau0:   tau0, phi: phi, q0: q0,
+	}
 
 	c.configurateSolver()
 
@@ -58,48 +118,57 @@ func (c *antColonySystem) localUpdatePheromone(a *ant) {
 	}
 }
 
-// Generate solutions for all ants.
+// Generate solutions for all ants, dispatching work across a pool of
+// goroutines sized by c.workers (0 means runtime.NumCPU()). The local
+// pheromone update mutates the shared matrix under c.mu's write lock, and
+// chooseNode takes its read lock, since both run concurrently across ants.
 func (c *antColonySystem) constructAntSolutions() {
 	c.resetAnts()
-	for _, a := range c.ants {
+	c.dispatchAnts(func(a *ant) {
 		c.antSimulation(a)
+		c.mu.Lock()
 		c.localUpdatePheromone(a)
-	}
+		c.mu.Unlock()
+	})
 }
 
 // Generate solution for a single ant.
 func (c *antColonySystem) antSimulation(a *ant) {
-	a.visitNode(rand.Intn(a.size))
+	a.visitNode(a.rng.Intn(a.size))
 	for i := 1; i < a.size; i++ {
 		a.visitNode(c.chooseNode(a))
-		a.cost += a.graph[a.path[len(a.path)-2]][a.path[len(a.path)-1]]
+		a.cost += c.problem.EdgeCost(i-1, a.path[len(a.path)-2], a.path[len(a.path)-1])
 	}
-	a.cost += a.graph[a.path[len(a.path)-1]][a.path[0]]
+	a.cost += c.problem.EdgeCost(c.size-1, a.path[len(a.path)-1], a.path[0])
+	a.refine(c.localSearch)
 }
 
-// Choose next node using pheromone trails.
+// Choose next node using pheromone trails. Reads c.pheromones under
+// c.mu's read lock since localUpdatePheromone deposits into it concurrently
+// from other ants' goroutines during construction.
 func (c *antColonySystem) chooseNode(a *ant) int {
 	probabilities := make([]float64, c.size)
 	var sum float64 = 0.0
 	var p float64
 	var pMax float64
 
-	for next := 0; next < a.size; next++ {
-		previous := a.path[len(a.path)-1]
-
-		if !a.visited[next] {
-			p = math.Pow(a.pheromones[previous][next], c.alfa) * math.Pow(a.weights[previous][next], c.beta)
-			probabilities[next] = p
-			sum += p
-			if p > pMax {
-				pMax = p
-			}
+	step := len(a.path) - 1
+	previous := a.path[len(a.path)-1]
+	c.mu.RLock()
+	for _, next := range c.candidatesFor(a) {
+		weight := c.tau0 * c.problem.HeuristicWeight(step, previous, next)
+		p = math.Pow(a.pheromones[previous][next], c.alfa) * math.Pow(weight, c.beta)
+		probabilities[next] = p
+		sum += p
+		if p > pMax {
+			pMax = p
 		}
 	}
+	c.mu.RUnlock()
 
 	probabilities, sum = c.exploitPheromones(a, probabilities, pMax, sum)
 
-	next := simulateChoice(probabilities, sum)
+	next := simulateChoice(probabilities, sum, a.rng)
 	return next
 }
 
@@ -109,7 +178,7 @@ func (c *antColonySystem) exploitPheromones(a *ant, probabilities []float64, pMa
 	var q float64
 
 	for i := range probabilities {
-		q = rand.Float64()
+		q = a.rng.Float64()
 		probabilities[i] /= sum
 		if q < c.q0 {
 			newSum = pMax - probabilities[i]
@@ -121,30 +190,35 @@ func (c *antColonySystem) exploitPheromones(a *ant, probabilities []float64, pMa
 
 // Configurate solver.
 func (c *antColonySystem) configurateSolver() {
-	c.size = len(c.graph)
+	if c.problem == nil {
+		c.problem = NewATSPProblem(c.graph)
+	}
+	c.size = c.problem.NumSteps()
 	c.generateMatrices()
 	c.makeAnts()
 
+	if c.graph != nil && c.candidateListSize > 0 {
+		c.buildCandidateLists()
+	}
+
 	c.bestPath = make([]int, c.size)
 	c.bestCost = 0
 }
 
-// Generates pheromone- and weight-matrices.
+// Generates the pheromone matrix. Heuristic weights are no longer
+// precomputed here; chooseNode asks the Problem for them at the actual
+// decision step instead (see ant.chooseNode).
 func (c *antColonySystem) generateMatrices() {
-	weights := make([][]float64, c.size)
 	pheromones := make([][]float64, c.size)
 
 	for i := 0; i < c.size; i++ {
-		weights[i] = make([]float64, c.size)
 		pheromones[i] = make([]float64, c.size)
 	}
 
 	for i := 0; i < c.size; i++ {
 		for j := 0; j < c.size; j++ {
 			pheromones[i][j] = c.tau0
-			weights[i][j] = c.tau0 / float64(c.graph[i][j])
 		}
 	}
-	c.weights = weights
 	c.pheromones = pheromones
 }
@@ -1,14 +1,16 @@
 package main
 
 import (
-	"math"
-	"math/rand"
+	"context"
 )
 
 // Solve ATSP returns optimal cost and solution to the ATSP specified by matrix graph.
 // The solution is heavily dependent on specified values for alfa, beta, rho, q & m.
-func SolveAS(graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, iterations int) (float64, []int) {
-	c := colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m}
+// localSearch selects an optional tour-refinement pass applied to each ant before pheromone deposition.
+// workers bounds how many goroutines construct ant tours concurrently (0 means runtime.NumCPU()).
+// candidateListSize restricts chooseNode to each node's K nearest neighbors (0 disables the restriction).
+func SolveAS(graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, iterations int, localSearch LocalSearch, workers int, candidateListSize int) (float64, []int) {
+	c := colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers, candidateListSize: candidateListSize}
 
 	c.configurateSolver()
 
@@ -22,86 +24,52 @@ func SolveAS(graph [][]float64, alfa float64, beta float64, rho float64, q float
 	return c.bestCost, c.bestPath
 }
 
-// Generate solutions for all ants.
-func (c *colony) constructAntSolutions() {
-	c.resetAnts()
-	for _, a := range c.ants {
-		c.antSimulation(a)
-	}
-}
+// SolveASFrom is SolveAS with an optional warm-start Snapshot and a context
+// that can cancel the run between generations, returning whatever is
+// currently the best-known solution along with a Snapshot for checkpointing.
+// It returns an error, without running any generations, if snapshot was
+// taken against a differently-sized problem.
+func SolveASFrom(ctx context.Context, graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, iterations int, localSearch LocalSearch, workers int, candidateListSize int, snapshot *Snapshot) (float64, []int, Snapshot, error) {
+	c := colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers, candidateListSize: candidateListSize}
 
-// Generate solution for a single ant.
-func (c *colony) antSimulation(a *ant) {
-	a.visitNode(rand.Intn(a.size))
-	for i := 1; i < a.size; i++ {
-		a.visitNode(c.chooseNode(a))
-		a.cost += a.graph[a.path[len(a.path)-2]][a.path[len(a.path)-1]]
-	}
-	a.cost += a.graph[a.path[len(a.path)-1]][a.path[0]]
-}
-
-// Choose next node using pheromone trails.
-func (c *colony) chooseNode(a *ant) int {
-	probabilities := make([]float64, c.size)
-	var sum float64 = 0.0
-	var p float64
-	for next := 0; next < a.size; next++ {
-		previous := a.path[len(a.path)-1]
-		if !a.visited[next] {
-			p = math.Pow(a.pheromones[previous][next], a.alfa) * math.Pow(a.weights[previous][next], a.beta)
-			probabilities[next] = p
-			sum += p
+	c.configurateSolver()
+	if snapshot != nil {
+		if err := c.Restore(*snapshot); err != nil {
+			return 0, nil, Snapshot{}, err
 		}
 	}
-	next := simulateChoice(probabilities, sum)
-	return next
-}
 
-// Update pheromones.
-func (c *colony) updatePheromones() {
-	for i := 0; i < c.size; i++ {
-		for j := 0; j < c.size; j++ {
-			c.pheromones[i][j] *= (1 - c.rho)
+	for ; c.gen < iterations; c.gen++ {
+		select {
+		case <-ctx.Done():
+			return c.bestCost, c.bestPath, c.Snapshot(), nil
+		default:
 		}
-	}
-	for _, a := range c.ants {
-
-		weight := c.q / a.cost
-
-		c.pheromones[a.path[c.size-1]][a.path[0]] += weight
 
-		for i := 1; i < c.size; i++ {
-			c.pheromones[a.path[i-1]][a.path[i]] += weight
-		}
+		c.constructAntSolutions()
+		c.updateBestPath()
+		c.updatePheromones()
 	}
+
+	return c.bestCost, c.bestPath, c.Snapshot(), nil
 }
 
-// Generates pheromone- and weight-matrices.
-func (c *colony) generateMatrices() {
-	weights := make([][]float64, c.size)
-	pheromones := make([][]float64, c.size)
+// SolveASProblem is SolveAS generalized to any Problem, not just the ATSP
+// adjacency matrices ATSPProblem wraps. This is what lets assignment,
+// scheduling or subset-selection problems reuse the AS engine. Candidate
+// lists are not available here since they rely on graph edge distances, and
+// for the same reason localSearch is silently skipped (see ant.refine).
+func SolveASProblem(problem Problem, alfa float64, beta float64, rho float64, q float64, m int, iterations int, localSearch LocalSearch, workers int) (float64, []int) {
+	c := colony{problem: problem, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers}
 
-	for i := 0; i < c.size; i++ {
-		weights[i] = make([]float64, c.size)
-		pheromones[i] = make([]float64, c.size)
-	}
+	c.configurateSolver()
 
-	for i := 0; i < c.size; i++ {
-		for j := 0; j < c.size; j++ {
-			pheromones[i][j] = 1.0
-			weights[i][j] = 1.0 / float64(c.graph[i][j])
-		}
-	}
-	c.weights = weights
-	c.pheromones = pheromones
-}
+	for gen := 0; gen < iterations; gen++ {
+		c.constructAntSolutions()
+		c.updateBestPath()
+		c.updatePheromones()
 
-// Configurate solver.
-func (c *colony) configurateSolver() {
-	c.size = len(c.graph)
-	c.generateMatrices()
-	c.makeAnts()
+	}
 
-	c.bestPath = make([]int, c.size)
-	c.bestCost = 0
+	return c.bestCost, c.bestPath
 }
@@ -1,4 +1,9 @@
-package ACO
+package main
+
+import (
+	"context"
+	"math"
+)
 
 // A colony of ants.
 type mmasColony struct {
@@ -9,9 +14,69 @@ type mmasColony struct {
 
 // Solve ATSP returns optimal cost and solution to the ATSP specified by matrix graph.
 // The solution is heavily dependent on specified values for alfa, beta, rho, q, m & tauMin/Max.
-func SolveMMAS(graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, tauMax float64, tauMin float64, iterations int) (float64, []int) {
-	parent := colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m}
-	c := mmasColony{colony: parent, tauMax: tauMax, tauMin: tauMin}
+// localSearch selects an optional tour-refinement pass applied to each ant before pheromone deposition.
+// workers bounds how many goroutines construct ant tours concurrently (0 means runtime.NumCPU()).
+// candidateListSize restricts chooseNode to each node's K nearest neighbors (0 disables the restriction).
+func SolveMMAS(graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, tauMax float64, tauMin float64, iterations int, localSearch LocalSearch, workers int, candidateListSize int) (float64, []int) {
+	c := mmasColony{
+		colony: colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers, candidateListSize: candidateListSize},
+		tauMax: tauMax, tauMin: tauMin,
+	}
+
+	c.configurateSolver()
+
+	for gen := 0; gen < iterations; gen++ {
+		c.constructAntSolutions()
+		c.updateBestPath()
+		c.updatePheromones()
+
+	}
+
+	return c.bestCost, c.bestPath
+}
+
+// SolveMMASFrom is SolveMMAS with an optional warm-start Snapshot and a
+// context that can cancel the run between generations, returning whatever
+// is currently the best-known solution along with a Snapshot for
+// checkpointing. It returns an error, without running any generations, if
+// snapshot was taken against a differently-sized problem.
+func SolveMMASFrom(ctx context.Context, graph [][]float64, alfa float64, beta float64, rho float64, q float64, m int, tauMax float64, tauMin float64, iterations int, localSearch LocalSearch, workers int, candidateListSize int, snapshot *Snapshot) (float64, []int, Snapshot, error) {
+	c := mmasColony{
+		colony: colony{graph: graph, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers, candidateListSize: candidateListSize},
+		tauMax: tauMax, tauMin: tauMin,
+	}
+
+	c.configurateSolver()
+	if snapshot != nil {
+		if err := c.Restore(*snapshot); err != nil {
+			return 0, nil, Snapshot{}, err
+		}
+	}
+
+	for ; c.gen < iterations; c.gen++ {
+		select {
+		case <-ctx.Done():
+			return c.bestCost, c.bestPath, c.Snapshot(), nil
+		default:
+		}
+
+		c.constructAntSolutions()
+		c.updateBestPath()
+		c.updatePheromones()
+	}
+
+	return c.bestCost, c.bestPath, c.Snapshot(), nil
+}
+
+// SolveMMASProblem is SolveMMAS generalized to any Problem, not just the
+// ATSP adjacency matrices ATSPProblem wraps. Candidate lists are not
+// available here since they rely on graph edge distances, and for the same
+// reason localSearch is silently skipped (see ant.refine).
+func SolveMMASProblem(problem Problem, alfa float64, beta float64, rho float64, q float64, m int, tauMax float64, tauMin float64, iterations int, localSearch LocalSearch, workers int) (float64, []int) {
+	c := mmasColony{
+		colony: colony{problem: problem, alfa: alfa, beta: beta, rho: rho, q: q, m: m, localSearch: localSearch, workers: workers},
+		tauMax: tauMax, tauMin: tauMin,
+	}
 
 	c.configurateSolver()
 
@@ -60,30 +125,70 @@ func (c *mmasColony) scalePheromones() {
 
 // Configurate solver.
 func (c *mmasColony) configurateSolver() {
-	c.size = len(c.graph)
+	if c.problem == nil {
+		c.problem = NewATSPProblem(c.graph)
+	}
+	c.size = c.problem.NumSteps()
 	c.generateMatrices()
 	c.makeAnts()
 
+	if c.graph != nil && c.candidateListSize > 0 {
+		c.buildCandidateLists()
+	}
+
 	c.bestPath = make([]int, c.size)
 	c.bestCost = 0
 }
 
-// Generates pheromone- and weight-matrices.
+// Generate solutions for all ants, dispatching work across a pool of
+// goroutines sized by c.workers (0 means runtime.NumCPU()).
+func (c *mmasColony) constructAntSolutions() {
+	c.resetAnts()
+	c.dispatchAnts(func(a *ant) { c.antSimulation(a) })
+}
+
+// Generate solution for a single ant.
+func (c *mmasColony) antSimulation(a *ant) {
+	a.visitNode(a.rng.Intn(a.size))
+	for i := 1; i < a.size; i++ {
+		a.visitNode(c.chooseNode(a))
+		a.cost += c.problem.EdgeCost(i-1, a.path[len(a.path)-2], a.path[len(a.path)-1])
+	}
+	a.cost += c.problem.EdgeCost(c.size-1, a.path[len(a.path)-1], a.path[0])
+	a.refine(c.localSearch)
+}
+
+// Choose next node using pheromone trails.
+func (c *mmasColony) chooseNode(a *ant) int {
+	probabilities := make([]float64, c.size)
+	var sum float64 = 0.0
+	var p float64
+	step := len(a.path) - 1
+	previous := a.path[len(a.path)-1]
+	for _, next := range c.candidatesFor(a) {
+		weight := c.tauMax * c.problem.HeuristicWeight(step, previous, next)
+		p = math.Pow(a.pheromones[previous][next], c.alfa) * math.Pow(weight, c.beta)
+		probabilities[next] = p
+		sum += p
+	}
+	next := simulateChoice(probabilities, sum, a.rng)
+	return next
+}
+
+// Generates the pheromone matrix. Heuristic weights are no longer
+// precomputed here; chooseNode asks the Problem for them at the actual
+// decision step instead.
 func (c *mmasColony) generateMatrices() {
-	weights := make([][]float64, c.size)
 	pheromones := make([][]float64, c.size)
 
 	for i := 0; i < c.size; i++ {
-		weights[i] = make([]float64, c.size)
 		pheromones[i] = make([]float64, c.size)
 	}
 
 	for i := 0; i < c.size; i++ {
 		for j := 0; j < c.size; j++ {
 			pheromones[i][j] = c.tauMax
-			weights[i][j] = c.tauMax / float64(c.graph[i][j])
 		}
 	}
-	c.weights = weights
 	c.pheromones = pheromones
 }
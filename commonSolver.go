@@ -1,26 +1,175 @@
 package main
 
-import "math"
-
 type solver interface {
 	updatePheromones()
 	constructAntSolutions()
 	chooseNode()
 }
 
-// Choose next node using pheromone trails.
-func (c *colony) chooseNode(a *ant) int {
-	probabilities := make([]float64, c.size)
-	var sum float64 = 0.0
-	var p float64
-	for next := 0; next < a.size; next++ {
-		previous := a.path[len(a.path)-1]
-		if !a.visited[next] {
-			p = math.Pow(a.pheromones[previous][next], a.alfa) * math.Pow(a.weights[previous][next], a.beta)
-			probabilities[next] = p
-			sum += p
+// LocalSearch selects the tour-refinement pass applied to each ant's path
+// before it is used for pheromone deposition.
+type LocalSearch int
+
+const (
+	// None disables local search; the constructed tour is used as-is.
+	None LocalSearch = iota
+	// TwoOpt repeatedly reverses sub-tours that reduce cost.
+	TwoOpt
+	// TwoPointFiveOpt applies TwoOpt followed by single-node reinsertion
+	// moves.
+	TwoPointFiveOpt
+)
+
+// maxLocalSearchPasses bounds the number of full improvement sweeps so a
+// pathological instance can't stall a generation indefinitely.
+const maxLocalSearchPasses = 1000
+
+// refine applies the requested local search to the ant's completed tour.
+// The moves below read pairwise edge costs directly off the graph matrix
+// for arbitrary (not just sequential) city pairs, which the step-indexed
+// Problem interface has no equivalent for, so local search is only
+// available for graph-backed colonies (plain SolveAS/SolveACS/SolveMMAS).
+// Problem-only colonies (SolveASProblem and friends) silently skip it.
+func (a *ant) refine(ls LocalSearch) {
+	if a.graph == nil {
+		return
+	}
+	switch ls {
+	case TwoOpt:
+		a.twoOpt()
+	case TwoPointFiveOpt:
+		a.twoOpt()
+		a.reinsert()
+	}
+}
+
+// twoOpt repeatedly reverses sub-tours between pairs of cities whenever
+// doing so reduces cost, until no improving move remains or the pass cap is
+// reached. Because the graph is asymmetric, reversing a segment flips the
+// direction of every edge inside it, so the delta is computed by summing
+// directed edge costs along the reversed segment rather than the simple
+// two-edge swap used for symmetric TSP.
+func (a *ant) twoOpt() {
+	improved := true
+	for pass := 0; improved && pass < maxLocalSearchPasses; pass++ {
+		improved = false
+		for i := 0; i < a.size-1; i++ {
+			for j := i + 1; j < a.size; j++ {
+				if delta := a.reverseDelta(i, j); delta < -1e-9 {
+					a.reverseSegment(i, j)
+					a.cost += delta
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+// reverseDelta returns the change in tour cost from reversing path[i:j+1].
+func (a *ant) reverseDelta(i, j int) float64 {
+	n := a.size
+
+	if i == 0 && j == n-1 {
+		// Reversing the whole tour has no edge outside the segment to anchor
+		// a boundary term on: every edge, including the wrap-around edge, is
+		// inside the reversed segment and simply runs backward afterward.
+		var delta float64
+		for k := 0; k < n; k++ {
+			next := (k + 1) % n
+			delta += a.graph[a.path[next]][a.path[k]] - a.graph[a.path[k]][a.path[next]]
+		}
+		return delta
+	}
+
+	prev := (i - 1 + n) % n
+	next := (j + 1) % n
+
+	var oldCost, newCost float64
+	oldCost += a.graph[a.path[prev]][a.path[i]]
+	oldCost += a.graph[a.path[j]][a.path[next]]
+	newCost += a.graph[a.path[prev]][a.path[j]]
+	newCost += a.graph[a.path[i]][a.path[next]]
+
+	for k := i; k < j; k++ {
+		oldCost += a.graph[a.path[k]][a.path[k+1]]
+		newCost += a.graph[a.path[k+1]][a.path[k]]
+	}
+
+	return newCost - oldCost
+}
+
+// reverseSegment reverses path[i:j+1] in place.
+func (a *ant) reverseSegment(i, j int) {
+	for i < j {
+		a.path[i], a.path[j] = a.path[j], a.path[i]
+		i++
+		j--
+	}
+}
+
+// reinsert tries, for every city in turn, removing it and reinserting it at
+// whichever position reduces tour cost the most ("2.5-opt").
+func (a *ant) reinsert() {
+	improved := true
+	for pass := 0; improved && pass < maxLocalSearchPasses; pass++ {
+		improved = false
+		for i := 0; i < a.size; i++ {
+			if a.reinsertCity(i) {
+				improved = true
+			}
+		}
+	}
+}
+
+// reinsertCity removes the city at position i and moves it to the position
+// that minimizes tour cost. It reports whether an improving move was made.
+func (a *ant) reinsertCity(i int) bool {
+	n := a.size
+	city := a.path[i]
+	prev := (i - 1 + n) % n
+	next := (i + 1) % n
+	removed := a.graph[a.path[prev]][city] + a.graph[city][a.path[next]] - a.graph[a.path[prev]][a.path[next]]
+
+	bestDelta := 0.0
+	bestPos := -1
+
+	for k := 0; k < n; k++ {
+		if k == i || k == prev {
+			continue
+		}
+		kNext := (k + 1) % n
+		if kNext == i {
+			continue
+		}
+		added := a.graph[a.path[k]][city] + a.graph[city][a.path[kNext]] - a.graph[a.path[k]][a.path[kNext]]
+		if delta := added - removed; delta < bestDelta-1e-9 {
+			bestDelta = delta
+			bestPos = k
+		}
+	}
+
+	if bestPos == -1 {
+		return false
+	}
+
+	a.movePath(i, bestPos)
+	a.cost += bestDelta
+	return true
+}
+
+// movePath removes the city at index i and reinserts it immediately after
+// index k.
+func (a *ant) movePath(i, k int) {
+	city := a.path[i]
+	path := make([]int, 0, a.size)
+	for idx, c := range a.path {
+		if idx == i {
+			continue
+		}
+		path = append(path, c)
+		if idx == k {
+			path = append(path, city)
 		}
 	}
-	next := simulateChoice(probabilities, sum)
-	return next
+	a.path = path
 }
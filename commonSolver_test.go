@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func tourCost(graph [][]float64, path []int) float64 {
+	n := len(path)
+	var cost float64
+	for i := 0; i < n; i++ {
+		cost += graph[path[i]][path[(i+1)%n]]
+	}
+	return cost
+}
+
+// TestReverseDeltaMatchesActualCost checks reverseDelta's prediction against
+// the real cost difference for every (i, j) pair on an asymmetric graph,
+// including the i=0, j=size-1 case that reverses the entire cyclic tour.
+func TestReverseDeltaMatchesActualCost(t *testing.T) {
+	graph := [][]float64{
+		{0, 5, 19, 3, 9, 4},
+		{16, 0, 15, 16, 13, 7},
+		{4, 16, 0, 1, 13, 14},
+		{20, 1, 15, 0, 9, 8},
+		{19, 4, 11, 1, 0, 1},
+		{1, 18, 1, 13, 7, 0},
+	}
+	path := []int{0, 1, 2, 3, 4, 5}
+	a := &ant{colony: &colony{graph: graph, size: len(path)}, path: append([]int(nil), path...)}
+
+	base := tourCost(graph, path)
+	for i := 0; i < a.size-1; i++ {
+		for j := i + 1; j < a.size; j++ {
+			got := a.reverseDelta(i, j)
+
+			reversed := append([]int(nil), path...)
+			for l, r := i, j; l < r; l, r = l+1, r-1 {
+				reversed[l], reversed[r] = reversed[r], reversed[l]
+			}
+			want := tourCost(graph, reversed) - base
+
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("reverseDelta(%d, %d) = %v, want %v", i, j, got, want)
+			}
+		}
+	}
+}
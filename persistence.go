@@ -0,0 +1,75 @@
+package main
+
+import "fmt"
+
+// Snapshot is a serializable capture of a colony's optimization state. It
+// can be persisted between runs to checkpoint a long optimization, or fed
+// back in to warm-start a new run from a previous run's converged
+// pheromone trails.
+type Snapshot struct {
+	Pheromones [][]float64 `json:"pheromones"`
+	BestPath   []int       `json:"bestPath"`
+	BestCost   float64     `json:"bestCost"`
+	Generation int         `json:"generation"`
+	Alfa       float64     `json:"alfa"`
+	Beta       float64     `json:"beta"`
+	Rho        float64     `json:"rho"`
+	Q          float64     `json:"q"`
+	M          int         `json:"m"`
+}
+
+// Snapshot captures the colony's current pheromone matrix, best-known
+// solution, generation counter and hyperparameters.
+func (c *colony) Snapshot() Snapshot {
+	pheromones := make([][]float64, len(c.pheromones))
+	for i, row := range c.pheromones {
+		pheromones[i] = append([]float64(nil), row...)
+	}
+
+	return Snapshot{
+		Pheromones: pheromones,
+		BestPath:   append([]int(nil), c.bestPath...),
+		BestCost:   c.bestCost,
+		Generation: c.gen,
+		Alfa:       c.alfa,
+		Beta:       c.beta,
+		Rho:        c.rho,
+		Q:          c.q,
+		M:          c.m,
+	}
+}
+
+// Restore seeds the colony's pheromone matrix, best-known solution,
+// generation counter and hyperparameters from a previously captured
+// Snapshot, enabling warm starts and resuming a paused run with the exact
+// settings it was checkpointed under. It returns an error instead of
+// restoring a mismatched snapshot, since silently loading a pheromone
+// matrix sized for a different problem would panic deep inside
+// chooseNode/updatePheromones instead of failing here with a clear cause.
+func (c *colony) Restore(snap Snapshot) error {
+	if len(snap.Pheromones) != c.size {
+		return fmt.Errorf("colony.Restore: snapshot has %d cities, colony has %d", len(snap.Pheromones), c.size)
+	}
+	for i, row := range snap.Pheromones {
+		if len(row) != c.size {
+			return fmt.Errorf("colony.Restore: snapshot row %d has %d entries, colony has %d", i, len(row), c.size)
+		}
+	}
+
+	pheromones := make([][]float64, len(snap.Pheromones))
+	for i, row := range snap.Pheromones {
+		pheromones[i] = append([]float64(nil), row...)
+	}
+
+	c.pheromones = pheromones
+	c.bestPath = append([]int(nil), snap.BestPath...)
+	c.bestCost = snap.BestCost
+	c.gen = snap.Generation
+	c.alfa = snap.Alfa
+	c.beta = snap.Beta
+	c.rho = snap.Rho
+	c.q = snap.Q
+	c.m = snap.M
+	c.makeAnts()
+	return nil
+}
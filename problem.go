@@ -0,0 +1,81 @@
+package main
+
+// Problem describes a combinatorial optimization problem that the ant
+// colony engines in this package can solve. Implementations decide how many
+// decisions an ant makes, which candidate values are legal at each step,
+// and how edges and completed solutions are scored. AS, MMAS and ACS all
+// drive an ant through this interface (only candidate lists and local
+// search, which need a graph-backed colony, read the graph matrix
+// directly), so any problem that can be
+// expressed as a sequence of choices over a candidate list — assignment,
+// scheduling, subset-selection, and so on — can reuse the same engines.
+type Problem interface {
+	// NumSteps returns the number of decisions an ant must make to build a
+	// complete solution.
+	NumSteps() int
+	// Candidates returns the values that may legally be chosen at step,
+	// given the partial solution built so far.
+	Candidates(step int, partial []int) []int
+	// EdgeCost returns the cost of moving from "from" to "to" at step.
+	EdgeCost(step int, from, to int) float64
+	// TourCost returns the total cost of a complete solution.
+	TourCost(solution []int) float64
+	// HeuristicWeight returns the heuristic desirability of moving from
+	// "from" to "to" at step, combined with pheromone strength when an ant
+	// chooses its next value.
+	HeuristicWeight(step int, from, to int) float64
+}
+
+// ATSPProblem adapts an asymmetric TSP weighted adjacency matrix to the
+// Problem interface; it is the default problem solved by SolveAS, SolveACS
+// and SolveMMAS when callers pass a graph directly.
+type ATSPProblem struct {
+	graph [][]float64
+}
+
+// NewATSPProblem returns a Problem that solves the ATSP described by graph.
+func NewATSPProblem(graph [][]float64) *ATSPProblem {
+	return &ATSPProblem{graph: graph}
+}
+
+// NumSteps returns one decision per city.
+func (p *ATSPProblem) NumSteps() int {
+	return len(p.graph)
+}
+
+// Candidates returns every city not yet present in partial.
+func (p *ATSPProblem) Candidates(step int, partial []int) []int {
+	visited := make([]bool, len(p.graph))
+	for _, n := range partial {
+		visited[n] = true
+	}
+	candidates := make([]int, 0, len(p.graph)-len(partial))
+	for n := range p.graph {
+		if !visited[n] {
+			candidates = append(candidates, n)
+		}
+	}
+	return candidates
+}
+
+// EdgeCost returns the directed weight from "from" to "to".
+func (p *ATSPProblem) EdgeCost(step int, from, to int) float64 {
+	return p.graph[from][to]
+}
+
+// TourCost sums the directed edges of a closed tour, including the return
+// to the starting city.
+func (p *ATSPProblem) TourCost(solution []int) float64 {
+	var cost float64
+	for i := 1; i < len(solution); i++ {
+		cost += p.graph[solution[i-1]][solution[i]]
+	}
+	cost += p.graph[solution[len(solution)-1]][solution[0]]
+	return cost
+}
+
+// HeuristicWeight is the classic ACO desirability for TSP: the inverse of
+// edge distance.
+func (p *ATSPProblem) HeuristicWeight(step int, from, to int) float64 {
+	return 1.0 / p.graph[from][to]
+}
@@ -7,28 +7,42 @@
 package main
 
 import (
-	"bufio"
+	"fmt"
 	"math"
 	"math/rand"
 	"os"
-	"regexp"
-	"strconv"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/BrumRn/Ant-Colony-Optimization/tsplib"
 )
 
+// DefaultCandidateListSize is the recommended K for candidateListSize on
+// TSPLIB-scale instances, trading a small amount of solution quality for a
+// much faster chooseNode.
+const DefaultCandidateListSize = 20
+
 // A colony of ants.
 type colony struct {
-	graph      [][]float64
-	weights    [][]float64
-	pheromones [][]float64
-	bestPath   []int
-	bestCost   float64
-	size       int
-	alfa       float64
-	beta       float64
-	rho        float64
-	m          int
-	q          float64
-	ants       []*ant
+	graph             [][]float64
+	pheromones        [][]float64
+	bestPath          []int
+	bestCost          float64
+	size              int
+	alfa              float64
+	beta              float64
+	rho               float64
+	m                 int
+	q                 float64
+	ants              []*ant
+	localSearch       LocalSearch
+	workers           int
+	mu                sync.RWMutex
+	problem           Problem
+	candidateListSize int
+	candidateLists    [][]int
+	gen               int
 }
 
 // A Single ant.
@@ -36,55 +50,26 @@ type ant struct {
 	cost    float64
 	path    []int
 	visited []bool
+	rng     *rand.Rand
 	*colony
 }
 
-// ReadATSP returns a graph matrix from an .atsp file.
-func ReadATSP(fileName string) [][]float64 {
-	file, _ := os.Open(fileName)
-
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-
-	var txt string
-	var n int
-	var match bool
-
-	scanner.Split(bufio.ScanWords)
-
-	for scanner.Scan() {
-		txt = scanner.Text()
-
-		if match {
-			n, _ = strconv.Atoi(scanner.Text())
-		}
-		match, _ = regexp.MatchString(txt, "DIMENSION:")
-
-		if txt == "EDGE_WEIGHT_SECTION" {
-			break
-		}
+// ReadATSP returns a graph matrix from an .atsp file, parsed with the full
+// TSPLIB reader in the tsplib subpackage. Unlike the file's previous
+// hand-rolled scanner, parse errors are returned instead of discarded.
+func ReadATSP(fileName string) ([][]float64, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("ReadATSP: opening %s: %w", fileName, err)
 	}
+	defer file.Close()
 
-	list := make([][]float64, n)
-
-	for i := 0; i < n; i++ {
-		list[i] = make([]float64, n)
+	inst, err := tsplib.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("ReadATSP: %w", err)
 	}
 
-	var i, j int
-	for scanner.Scan() {
-		if j == n {
-			i++
-			j = 0
-		}
-
-		if i == n {
-			break
-		}
-		list[i][j], _ = strconv.ParseFloat(scanner.Text(), 64)
-		j++
-	}
-	return list
+	return inst.Matrix, nil
 }
 
 // Solve ATSP returns optimal cost and solution to the ATSP specified by matrix graph.
@@ -104,22 +89,52 @@ func SolveATSP(graph [][]float64, alfa float64, beta float64, rho float64, q flo
 	return c.bestCost, c.bestPath
 }
 
-// Generate solutions for all ants.
+// Generate solutions for all ants, dispatching work across a pool of
+// goroutines sized by c.workers (0 means runtime.NumCPU()).
 func (c *colony) constructAntSolutions() {
 	c.resetAnts()
+	c.dispatchAnts(func(a *ant) { a.antSimulation() })
+}
+
+// dispatchAnts runs work for every ant in c.ants across a worker pool and
+// blocks until all ants are done.
+func (c *colony) dispatchAnts(work func(a *ant)) {
+	workers := c.workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(c.ants) {
+		workers = len(c.ants)
+	}
+
+	jobs := make(chan *ant, len(c.ants))
 	for _, a := range c.ants {
-		a.antSimulation()
+		jobs <- a
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for a := range jobs {
+				work(a)
+			}
+		}()
 	}
+	wg.Wait()
 }
 
 // Generate solution for a single ant.
 func (a *ant) antSimulation() {
-	a.visitNode(rand.Intn(a.size))
+	a.visitNode(a.rng.Intn(a.size))
 	for i := 1; i < a.size; i++ {
 		a.visitNode(a.chooseNode())
-		a.cost += a.graph[a.path[len(a.path)-2]][a.path[len(a.path)-1]]
+		a.cost += a.problem.EdgeCost(i-1, a.path[len(a.path)-2], a.path[len(a.path)-1])
 	}
-	a.cost += a.graph[a.path[len(a.path)-1]][a.path[0]]
+	a.cost += a.problem.EdgeCost(a.size-1, a.path[len(a.path)-1], a.path[0])
+	a.refine(a.localSearch)
 }
 
 // Visit node.
@@ -128,26 +143,28 @@ func (a *ant) visitNode(node int) {
 	a.visited[node] = true
 }
 
-// Choose next node using pheromone trails.
+// Choose next node using pheromone trails. The heuristic weight is asked
+// of the Problem fresh for every move (passing the real decision step, not
+// a precomputed index) so problems whose heuristic varies by step, such as
+// scheduling, are weighed correctly rather than frozen at setup time.
 func (a *ant) chooseNode() int {
 	probabilities := make([]float64, a.size)
 	var sum float64 = 0.0
 	var c float64
-	for next := 0; next < a.size; next++ {
-		previous := a.path[len(a.path)-1]
-		if !a.visited[next] {
-			c = math.Pow(a.pheromones[previous][next], a.alfa) * math.Pow(a.weights[previous][next], a.beta)
-			probabilities[next] = c
-			sum += c
-		}
+	step := len(a.path) - 1
+	previous := a.path[len(a.path)-1]
+	for _, next := range a.candidatesFor(a) {
+		c = math.Pow(a.pheromones[previous][next], a.alfa) * math.Pow(a.problem.HeuristicWeight(step, previous, next), a.beta)
+		probabilities[next] = c
+		sum += c
 	}
-	next := simulateChoice(probabilities, sum)
+	next := simulateChoice(probabilities, sum, a.rng)
 	return next
 }
 
 // Simulate choice using probability distribution.
-func simulateChoice(probabilities []float64, sum float64) int {
-	u := sum * rand.Float64()
+func simulateChoice(probabilities []float64, sum float64, rng *rand.Rand) int {
+	u := sum * rng.Float64()
 	node := 0
 	c := probabilities[node]
 	for c < u {
@@ -191,42 +208,116 @@ func (c *colony) updateBestPath() {
 	}
 }
 
-// Generates pheromone- and weight-matrices.
+// Generates the pheromone matrix. Heuristic weights are no longer
+// precomputed here; chooseNode asks the Problem for them at the actual
+// decision step instead (see ant.chooseNode).
 func (c *colony) generateMatrices() {
-	weights := make([][]float64, c.size)
 	pheromones := make([][]float64, c.size)
 
 	for i := 0; i < c.size; i++ {
-		weights[i] = make([]float64, c.size)
 		pheromones[i] = make([]float64, c.size)
 	}
 
 	for i := 0; i < c.size; i++ {
 		for j := 0; j < c.size; j++ {
 			pheromones[i][j] = 1.0
-			weights[i][j] = 1.0 / float64(c.graph[i][j])
 		}
 	}
-	c.weights = weights
 	c.pheromones = pheromones
 }
 
 // Configurate solver.
 func (c *colony) configurateSolver() {
-	c.size = len(c.graph)
+	if c.problem == nil {
+		c.problem = NewATSPProblem(c.graph)
+	}
+	c.size = c.problem.NumSteps()
 	c.generateMatrices()
 	c.makeAnts()
 
+	if c.graph != nil && c.candidateListSize > 0 {
+		c.buildCandidateLists()
+	}
+
 	c.bestPath = make([]int, c.size)
 	c.bestCost = 0
 }
 
-// Create m ants.
+// buildCandidateLists precomputes, for every node, its candidateListSize
+// nearest neighbors by outgoing edge distance. chooseNode restricts its
+// search to this list so the inner loop stays fast on large instances.
+func (c *colony) buildCandidateLists() {
+	lists := make([][]int, c.size)
+
+	for i := 0; i < c.size; i++ {
+		neighbors := make([]int, 0, c.size-1)
+		for j := 0; j < c.size; j++ {
+			if j != i {
+				neighbors = append(neighbors, j)
+			}
+		}
+		sort.Slice(neighbors, func(a, b int) bool {
+			return c.graph[i][neighbors[a]] < c.graph[i][neighbors[b]]
+		})
+
+		k := c.candidateListSize
+		if k > len(neighbors) {
+			k = len(neighbors)
+		}
+		lists[i] = neighbors[:k]
+	}
+
+	c.candidateLists = lists
+}
+
+// candidatesFor returns the nodes chooseNode should weigh next for a. When a
+// candidate list is configured it restricts the search to a's unvisited
+// nearest neighbors, falling back to the globally-nearest unvisited node
+// once that list is exhausted.
+func (c *colony) candidatesFor(a *ant) []int {
+	if c.candidateLists == nil {
+		return c.problem.Candidates(len(a.path), a.path)
+	}
+
+	previous := a.path[len(a.path)-1]
+	candidates := make([]int, 0, len(c.candidateLists[previous]))
+	for _, next := range c.candidateLists[previous] {
+		if !a.visited[next] {
+			candidates = append(candidates, next)
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates
+	}
+
+	if nearest := c.nearestUnvisited(a, previous); nearest != -1 {
+		return []int{nearest}
+	}
+	return nil
+}
+
+// nearestUnvisited scans outgoing edges from "from" for the closest node a
+// hasn't visited yet.
+func (c *colony) nearestUnvisited(a *ant, from int) int {
+	best := -1
+	bestDist := math.Inf(1)
+	for next := 0; next < c.size; next++ {
+		if !a.visited[next] && c.graph[from][next] < bestDist {
+			bestDist = c.graph[from][next]
+			best = next
+		}
+	}
+	return best
+}
+
+// Create m ants, each with its own RNG so concurrent construction never
+// shares a generator across goroutines. The seeds are drawn from the global
+// math/rand source, so results are not reproducible from run to run.
 func (c *colony) makeAnts() {
 	c.ants = make([]*ant, 0, c.m)
 
 	for i := 0; i < c.m; i++ {
-		a := &ant{colony: c}
+		a := &ant{colony: c, rng: rand.New(rand.NewSource(rand.Int63()))}
 		a.cost = 0
 		a.path = make([]int, 0, a.size)
 		a.visited = make([]bool, a.size)
@@ -0,0 +1,78 @@
+package tsplib
+
+import (
+	"fmt"
+	"math"
+)
+
+// matrixFromCoords builds a full n*n distance matrix from NODE_COORD_SECTION
+// points according to the instance's EDGE_WEIGHT_TYPE.
+func matrixFromCoords(coords [][2]float64, edgeWeightType string) ([][]float64, error) {
+	var dist func(a, b [2]float64) float64
+
+	switch edgeWeightType {
+	case "EUC_2D":
+		dist = euc2D
+	case "GEO":
+		dist = geo
+	case "ATT":
+		dist = att
+	default:
+		return nil, fmt.Errorf("tsplib: unsupported EDGE_WEIGHT_TYPE %q for NODE_COORD_SECTION", edgeWeightType)
+	}
+
+	n := len(coords)
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+		for j := range matrix[i] {
+			if i != j {
+				matrix[i][j] = dist(coords[i], coords[j])
+			}
+		}
+	}
+	return matrix, nil
+}
+
+// euc2D is the straight-line distance used by EUC_2D instances.
+func euc2D(a, b [2]float64) float64 {
+	dx := a[0] - b[0]
+	dy := a[1] - b[1]
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// geo converts TSPLIB's degrees.minutes coordinates to radians and returns
+// the great-circle distance in kilometers, following the formula from the
+// TSPLIB95 format specification.
+func geo(a, b [2]float64) float64 {
+	const degToRad = math.Pi / 180.0
+	const earthRadius = 6378.388
+
+	toRadians := func(coord float64) float64 {
+		deg := math.Trunc(coord)
+		min := coord - deg
+		return degToRad * (deg + 5.0*min/3.0)
+	}
+
+	lat1, lon1 := toRadians(a[0]), toRadians(a[1])
+	lat2, lon2 := toRadians(b[0]), toRadians(b[1])
+
+	q1 := math.Cos(lon1 - lon2)
+	q2 := math.Cos(lat1 - lat2)
+	q3 := math.Cos(lat1 + lat2)
+
+	return earthRadius*math.Acos(0.5*((1+q1)*q2-(1-q1)*q3)) + 1.0
+}
+
+// att is TSPLIB's pseudo-Euclidean distance used by the ATT-class
+// instances.
+func att(a, b [2]float64) float64 {
+	dx := a[0] - b[0]
+	dy := a[1] - b[1]
+	r := math.Sqrt((dx*dx + dy*dy) / 10.0)
+	t := math.Round(r)
+	if t < r {
+		return t + 1
+	}
+	return t
+}
@@ -0,0 +1,124 @@
+package tsplib
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenReader pulls whitespace-separated tokens across consecutive lines of
+// a shared scanner, since TSPLIB data sections wrap values at arbitrary
+// column widths.
+type tokenReader struct {
+	scanner *bufio.Scanner
+	tokens  []string
+}
+
+func (t *tokenReader) next() (string, bool) {
+	for len(t.tokens) == 0 {
+		if !t.scanner.Scan() {
+			return "", false
+		}
+		t.tokens = strings.Fields(t.scanner.Text())
+	}
+	tok := t.tokens[0]
+	t.tokens = t.tokens[1:]
+	return tok, true
+}
+
+func (t *tokenReader) readFloat() (float64, error) {
+	tok, ok := t.next()
+	if !ok {
+		return 0, fmt.Errorf("unexpected end of input")
+	}
+	return strconv.ParseFloat(tok, 64)
+}
+
+func (t *tokenReader) readInt() (int, error) {
+	tok, ok := t.next()
+	if !ok {
+		return 0, fmt.Errorf("unexpected end of input")
+	}
+	return strconv.Atoi(tok)
+}
+
+// readEdgeWeights reads an EDGE_WEIGHT_SECTION body and returns it as a
+// full, directed n*n matrix regardless of the on-disk format.
+func readEdgeWeights(scanner *bufio.Scanner, n int, format string) ([][]float64, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("tsplib: EDGE_WEIGHT_SECTION before DIMENSION")
+	}
+
+	tr := &tokenReader{scanner: scanner}
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+	}
+
+	switch format {
+	case "FULL_MATRIX":
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				v, err := tr.readFloat()
+				if err != nil {
+					return nil, fmt.Errorf("tsplib: reading FULL_MATRIX entry (%d,%d): %w", i, j, err)
+				}
+				matrix[i][j] = v
+			}
+		}
+	case "UPPER_ROW":
+		for i := 0; i < n-1; i++ {
+			for j := i + 1; j < n; j++ {
+				v, err := tr.readFloat()
+				if err != nil {
+					return nil, fmt.Errorf("tsplib: reading UPPER_ROW entry (%d,%d): %w", i, j, err)
+				}
+				matrix[i][j] = v
+				matrix[j][i] = v
+			}
+		}
+	case "LOWER_DIAG_ROW":
+		for i := 0; i < n; i++ {
+			for j := 0; j <= i; j++ {
+				v, err := tr.readFloat()
+				if err != nil {
+					return nil, fmt.Errorf("tsplib: reading LOWER_DIAG_ROW entry (%d,%d): %w", i, j, err)
+				}
+				matrix[i][j] = v
+				matrix[j][i] = v
+			}
+		}
+	case "FUNCTION":
+		return nil, fmt.Errorf("tsplib: EDGE_WEIGHT_FORMAT FUNCTION is not supported (the file stores no explicit distances)")
+	default:
+		return nil, fmt.Errorf("tsplib: unsupported EDGE_WEIGHT_FORMAT %q", format)
+	}
+
+	return matrix, nil
+}
+
+// readCoords reads a NODE_COORD_SECTION body of "id x y" triples.
+func readCoords(scanner *bufio.Scanner, n int) ([][2]float64, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("tsplib: NODE_COORD_SECTION before DIMENSION")
+	}
+
+	tr := &tokenReader{scanner: scanner}
+	coords := make([][2]float64, n)
+	for i := 0; i < n; i++ {
+		if _, err := tr.readInt(); err != nil {
+			return nil, fmt.Errorf("tsplib: reading node id %d: %w", i, err)
+		}
+		x, err := tr.readFloat()
+		if err != nil {
+			return nil, fmt.Errorf("tsplib: reading x coordinate for node %d: %w", i, err)
+		}
+		y, err := tr.readFloat()
+		if err != nil {
+			return nil, fmt.Errorf("tsplib: reading y coordinate for node %d: %w", i, err)
+		}
+		coords[i] = [2]float64{x, y}
+	}
+	return coords, nil
+}
@@ -0,0 +1,120 @@
+// Package tsplib parses and writes files in the TSPLIB format used by the
+// standard symmetric/asymmetric TSP benchmark instances.
+package tsplib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Type identifies the kind of problem a TSPLIB instance describes.
+type Type string
+
+// Recognized TSPLIB problem types.
+const (
+	TSP  Type = "TSP"
+	ATSP Type = "ATSP"
+)
+
+// Instance is a normalized, in-memory representation of a parsed TSPLIB
+// file. Matrix is always a full, directed n*n adjacency matrix regardless
+// of how the source file encoded its edge weights.
+type Instance struct {
+	Name           string
+	Type           Type
+	Dimension      int
+	EdgeWeightType string
+	Matrix         [][]float64
+}
+
+// Parse reads a TSPLIB-formatted instance from r. It understands EXPLICIT
+// edge weights given as FULL_MATRIX, UPPER_ROW or LOWER_DIAG_ROW, as well
+// as NODE_COORD_SECTION instances using EUC_2D, GEO or ATT, and returns an
+// error instead of silently producing a partial matrix.
+func Parse(r io.Reader) (*Instance, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	inst := &Instance{Type: TSP}
+	var format string
+	var coords [][2]float64
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line == "EOF" {
+			continue
+		}
+
+		if key, value, ok := splitSpec(line); ok {
+			switch key {
+			case "NAME":
+				inst.Name = value
+			case "TYPE":
+				inst.Type = Type(value)
+			case "DIMENSION":
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("tsplib: invalid DIMENSION %q: %w", value, err)
+				}
+				inst.Dimension = n
+			case "EDGE_WEIGHT_TYPE":
+				inst.EdgeWeightType = value
+			case "EDGE_WEIGHT_FORMAT":
+				format = value
+			}
+			continue
+		}
+
+		switch line {
+		case "NODE_COORD_SECTION":
+			pts, err := readCoords(scanner, inst.Dimension)
+			if err != nil {
+				return nil, err
+			}
+			coords = pts
+		case "EDGE_WEIGHT_SECTION":
+			if format == "" {
+				format = "FULL_MATRIX"
+			}
+			matrix, err := readEdgeWeights(scanner, inst.Dimension, format)
+			if err != nil {
+				return nil, err
+			}
+			inst.Matrix = matrix
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("tsplib: reading instance: %w", err)
+	}
+
+	if inst.Matrix == nil {
+		if coords == nil {
+			return nil, fmt.Errorf("tsplib: instance has neither EDGE_WEIGHT_SECTION nor NODE_COORD_SECTION")
+		}
+		matrix, err := matrixFromCoords(coords, inst.EdgeWeightType)
+		if err != nil {
+			return nil, err
+		}
+		inst.Matrix = matrix
+	}
+
+	if inst.Dimension == 0 {
+		inst.Dimension = len(inst.Matrix)
+	}
+
+	return inst, nil
+}
+
+// splitSpec splits a "KEY : value" specification line. Section markers
+// such as EDGE_WEIGHT_SECTION have no colon and are reported as not a spec.
+func splitSpec(line string) (key, value string, ok bool) {
+	idx := strings.Index(line, ":")
+	if idx < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:]), true
+}
@@ -0,0 +1,208 @@
+package tsplib
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestParseFullMatrix(t *testing.T) {
+	src := `NAME : test
+TYPE : ATSP
+DIMENSION : 3
+EDGE_WEIGHT_TYPE : EXPLICIT
+EDGE_WEIGHT_FORMAT : FULL_MATRIX
+EDGE_WEIGHT_SECTION
+0 1 2
+3 0 4
+5 6 0
+EOF
+`
+	inst, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := [][]float64{
+		{0, 1, 2},
+		{3, 0, 4},
+		{5, 6, 0},
+	}
+	for i := range want {
+		for j := range want[i] {
+			if inst.Matrix[i][j] != want[i][j] {
+				t.Errorf("Matrix[%d][%d] = %v, want %v", i, j, inst.Matrix[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestParseUpperRow(t *testing.T) {
+	src := `NAME : test
+TYPE : TSP
+DIMENSION : 4
+EDGE_WEIGHT_TYPE : EXPLICIT
+EDGE_WEIGHT_FORMAT : UPPER_ROW
+EDGE_WEIGHT_SECTION
+1 2 3
+4 5
+6
+EOF
+`
+	inst, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := [][]float64{
+		{0, 1, 2, 3},
+		{1, 0, 4, 5},
+		{2, 4, 0, 6},
+		{3, 5, 6, 0},
+	}
+	for i := range want {
+		for j := range want[i] {
+			if inst.Matrix[i][j] != want[i][j] {
+				t.Errorf("Matrix[%d][%d] = %v, want %v", i, j, inst.Matrix[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestParseLowerDiagRow(t *testing.T) {
+	src := `NAME : test
+TYPE : TSP
+DIMENSION : 3
+EDGE_WEIGHT_TYPE : EXPLICIT
+EDGE_WEIGHT_FORMAT : LOWER_DIAG_ROW
+EDGE_WEIGHT_SECTION
+0
+1 0
+2 3 0
+EOF
+`
+	inst, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := [][]float64{
+		{0, 1, 2},
+		{1, 0, 3},
+		{2, 3, 0},
+	}
+	for i := range want {
+		for j := range want[i] {
+			if inst.Matrix[i][j] != want[i][j] {
+				t.Errorf("Matrix[%d][%d] = %v, want %v", i, j, inst.Matrix[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestParseNodeCoordEUC2D(t *testing.T) {
+	src := `NAME : test
+TYPE : TSP
+DIMENSION : 2
+EDGE_WEIGHT_TYPE : EUC_2D
+NODE_COORD_SECTION
+1 0 0
+2 3 4
+EOF
+`
+	inst, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if !almostEqual(inst.Matrix[0][1], 5) {
+		t.Errorf("Matrix[0][1] = %v, want 5", inst.Matrix[0][1])
+	}
+	if !almostEqual(inst.Matrix[1][0], 5) {
+		t.Errorf("Matrix[1][0] = %v, want 5", inst.Matrix[1][0])
+	}
+}
+
+func TestParseNodeCoordGEO(t *testing.T) {
+	src := `NAME : test
+TYPE : TSP
+DIMENSION : 2
+EDGE_WEIGHT_TYPE : GEO
+NODE_COORD_SECTION
+1 38.24 20.42
+2 39.57 26.15
+EOF
+`
+	inst, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	const want = 509.9900953684253
+	if !almostEqual(inst.Matrix[0][1], want) {
+		t.Errorf("Matrix[0][1] = %v, want %v", inst.Matrix[0][1], want)
+	}
+}
+
+func TestParseNodeCoordATT(t *testing.T) {
+	src := `NAME : test
+TYPE : TSP
+DIMENSION : 2
+EDGE_WEIGHT_TYPE : ATT
+NODE_COORD_SECTION
+1 0 0
+2 3 4
+EOF
+`
+	inst, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if !almostEqual(inst.Matrix[0][1], 2) {
+		t.Errorf("Matrix[0][1] = %v, want 2", inst.Matrix[0][1])
+	}
+}
+
+func TestParseMissingSections(t *testing.T) {
+	src := `NAME : test
+TYPE : TSP
+DIMENSION : 2
+EOF
+`
+	if _, err := Parse(strings.NewReader(src)); err == nil {
+		t.Fatal("Parse: expected an error for an instance with no edge data, got nil")
+	}
+}
+
+func TestParseUnsupportedEdgeWeightFormat(t *testing.T) {
+	src := `NAME : test
+TYPE : TSP
+DIMENSION : 2
+EDGE_WEIGHT_TYPE : EXPLICIT
+EDGE_WEIGHT_FORMAT : FUNCTION
+EDGE_WEIGHT_SECTION
+EOF
+`
+	if _, err := Parse(strings.NewReader(src)); err == nil {
+		t.Fatal("Parse: expected an error for EDGE_WEIGHT_FORMAT FUNCTION, got nil")
+	}
+}
+
+func TestWriteTour(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteTour(&buf, []int{2, 0, 1}, 42.5); err != nil {
+		t.Fatalf("WriteTour: %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{"DIMENSION : 3", "COMMENT : Length 42.5", "TOUR_SECTION", "3\n1\n2\n", "-1\n", "EOF\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteTour output missing %q, got:\n%s", want, got)
+		}
+	}
+}
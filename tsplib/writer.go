@@ -0,0 +1,26 @@
+package tsplib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteTour writes tour (0-indexed city ids) and its cost to w as a TSPLIB
+// .tour file.
+func WriteTour(w io.Writer, tour []int, cost float64) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "NAME : tour\n")
+	fmt.Fprintf(bw, "TYPE : TOUR\n")
+	fmt.Fprintf(bw, "DIMENSION : %d\n", len(tour))
+	fmt.Fprintf(bw, "COMMENT : Length %g\n", cost)
+	fmt.Fprintf(bw, "TOUR_SECTION\n")
+	for _, city := range tour {
+		fmt.Fprintf(bw, "%d\n", city+1)
+	}
+	fmt.Fprintf(bw, "-1\n")
+	fmt.Fprintf(bw, "EOF\n")
+
+	return bw.Flush()
+}